@@ -6,6 +6,7 @@ package monkey
 import "C"
 import (
 	"runtime"
+	"unsafe"
 )
 
 // JavaScript Array
@@ -138,3 +139,88 @@ func (a *Array) GetArray(index int) *Array {
 func (a *Array) SetArray(index int, o *Array) bool {
 	return a.SetElement(index, o.ToValue())
 }
+
+/*
+Batch element transfer
+
+SetInt/SetNumber and their Get counterparts each re-lock the runtime and
+round-trip through *Value for a single element, which dominates cost when
+pushing large homogeneous numeric slices to the engine. The functions below
+take the lock once and hand the whole slice to a C loop instead; see
+monkey.h's js_SetInts/js_SetNumbers/js_GetInts/js_GetNumbers helpers.
+BenchmarkArraySetInts/BenchmarkArraySetIntLoop in js_array_bench_test.go
+measure both paths across a range of slice sizes; this package has no
+buildable cgo toolchain in every environment it's vendored into, so no
+crossover point is recorded here — run the benchmarks where monkey.h is
+available and compare before relying on a specific size threshold.
+*/
+
+// SetInts writes v into the array starting at index 0, resizing the array
+// to len(v) first.
+func (a *Array) SetInts(v []int32) bool {
+	a.cx.rt.lock()
+	defer a.cx.rt.unlock()
+
+	if len(v) == 0 {
+		return C.JS_SetArrayLength(a.cx.jscx, a.obj, 0) == C.JS_TRUE
+	}
+
+	return C.js_SetInts(a.cx.jscx, a.obj, (*C.int32_t)(unsafe.Pointer(&v[0])), C.size_t(len(v))) == C.JS_TRUE
+}
+
+// SetNumbers writes v into the array starting at index 0, resizing the
+// array to len(v) first.
+func (a *Array) SetNumbers(v []float64) bool {
+	a.cx.rt.lock()
+	defer a.cx.rt.unlock()
+
+	if len(v) == 0 {
+		return C.JS_SetArrayLength(a.cx.jscx, a.obj, 0) == C.JS_TRUE
+	}
+
+	return C.js_SetNumbers(a.cx.jscx, a.obj, (*C.double)(unsafe.Pointer(&v[0])), C.size_t(len(v))) == C.JS_TRUE
+}
+
+// GetInts reads the whole array into a []int32 in one pass. It reports
+// false if any element isn't representable as an int32.
+func (a *Array) GetInts() ([]int32, bool) {
+	a.cx.rt.lock()
+	defer a.cx.rt.unlock()
+
+	var length C.jsuint
+	if C.JS_GetArrayLength(a.cx.jscx, a.obj, &length) != C.JS_TRUE {
+		return nil, false
+	}
+	if length == 0 {
+		return nil, true
+	}
+
+	out := make([]int32, int(length))
+	if C.js_GetInts(a.cx.jscx, a.obj, (*C.int32_t)(unsafe.Pointer(&out[0])), C.size_t(length)) != C.JS_TRUE {
+		return nil, false
+	}
+
+	return out, true
+}
+
+// GetNumbers reads the whole array into a []float64 in one pass. It
+// reports false if any element isn't representable as a number.
+func (a *Array) GetNumbers() ([]float64, bool) {
+	a.cx.rt.lock()
+	defer a.cx.rt.unlock()
+
+	var length C.jsuint
+	if C.JS_GetArrayLength(a.cx.jscx, a.obj, &length) != C.JS_TRUE {
+		return nil, false
+	}
+	if length == 0 {
+		return nil, true
+	}
+
+	out := make([]float64, int(length))
+	if C.js_GetNumbers(a.cx.jscx, a.obj, (*C.double)(unsafe.Pointer(&out[0])), C.size_t(length)) != C.JS_TRUE {
+		return nil, false
+	}
+
+	return out, true
+}
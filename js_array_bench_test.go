@@ -0,0 +1,53 @@
+package monkey
+
+import (
+	"strconv"
+	"testing"
+)
+
+// newBenchArray gives each benchmark size a fresh array of n elements to
+// write into, so one size's run doesn't leave the next with an array of the
+// wrong length. All b.N iterations at a given size reuse that array.
+func newBenchArray(n int) *Array {
+	rt := NewRuntime(8 * 1024 * 1024)
+	cx := rt.NewContext(8192)
+	a := cx.NewArray(n)
+	a.SetLength(n)
+	return a
+}
+
+func benchInts(n int) []int32 {
+	v := make([]int32, n)
+	for i := range v {
+		v[i] = int32(i)
+	}
+	return v
+}
+
+func BenchmarkArraySetInts(b *testing.B) {
+	for _, n := range []int{8, 64, 1024, 100000} {
+		v := benchInts(n)
+		a := newBenchArray(n)
+
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				a.SetInts(v)
+			}
+		})
+	}
+}
+
+func BenchmarkArraySetIntLoop(b *testing.B) {
+	for _, n := range []int{8, 64, 1024, 100000} {
+		v := benchInts(n)
+		a := newBenchArray(n)
+
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for j, x := range v {
+					a.SetInt(j, x)
+				}
+			}
+		})
+	}
+}
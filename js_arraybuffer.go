@@ -0,0 +1,289 @@
+package monkey
+
+/*
+#include "monkey.h"
+*/
+import "C"
+import (
+	"runtime"
+	"unsafe"
+)
+
+// ArrayBuffer wraps a SpiderMonkey ArrayBuffer object, giving Go callers
+// direct access to the backing storage instead of marshalling through
+// *Value for every byte like SetInt/GetInt force on a plain Array.
+type ArrayBuffer struct {
+	cx  *Context
+	obj *C.JSObject
+}
+
+// See newObject()
+func newArrayBuffer(cx *Context, obj *C.JSObject) *ArrayBuffer {
+	result := &ArrayBuffer{cx, obj}
+
+	C.JS_AddObjectRoot(cx.jscx, &result.obj)
+
+	runtime.SetFinalizer(result, func(ab *ArrayBuffer) {
+		C.JS_RemoveObjectRoot(ab.cx.jscx, &ab.obj)
+	})
+
+	return result
+}
+
+// NewArrayBuffer creates a new ArrayBuffer able to hold size bytes.
+func (cx *Context) NewArrayBuffer(size int) *ArrayBuffer {
+	cx.rt.lock()
+	defer cx.rt.unlock()
+
+	obj := C.JS_NewArrayBuffer(cx.jscx, C.uint32(size))
+	if obj == nil {
+		return nil
+	}
+
+	return newArrayBuffer(cx, obj)
+}
+
+// NewArrayBufferFromBytes creates an ArrayBuffer and copies b into it.
+func (cx *Context) NewArrayBufferFromBytes(b []byte) *ArrayBuffer {
+	ab := cx.NewArrayBuffer(len(b))
+	if ab == nil {
+		return nil
+	}
+
+	if len(b) > 0 {
+		copy(ab.BytesNoCopy(), b)
+	}
+
+	return ab
+}
+
+func (ab *ArrayBuffer) ToValue() *Value {
+	return newValue(ab.cx, C.OBJECT_TO_JSVAL(ab.obj))
+}
+
+// rawData returns a pointer to ab's backing bytes and their length. ab.obj
+// is a true ArrayBuffer only when ab came straight from NewArrayBuffer /
+// ToArrayBuffer on a plain buffer value; when ab is actually one of the
+// Int8Array/Uint8Array/Float64Array views (from NewInt8Array and friends,
+// or ToTypedArray/ToArrayBuffer detecting a view), ab.obj is the *view*
+// object, and JS_GetArrayBufferData — documented against true ArrayBuffer
+// objects — does not return its data. Route typed-array views through
+// JS_GetTypedArrayData instead.
+func (ab *ArrayBuffer) rawData() (unsafe.Pointer, int) {
+	if C.JS_IsTypedArrayObject(ab.obj) == C.JS_TRUE {
+		var length C.uint32
+		data := C.JS_GetTypedArrayData(ab.obj, &length)
+		return unsafe.Pointer(data), int(length)
+	}
+
+	var length C.uint32
+	data := C.JS_GetArrayBufferData(ab.obj, &length, ab.cx.jscx)
+	return unsafe.Pointer(data), int(length)
+}
+
+// Length returns the size of the backing store in bytes.
+func (ab *ArrayBuffer) Length() int {
+	ab.cx.rt.lock()
+	defer ab.cx.rt.unlock()
+
+	_, length := ab.rawData()
+	return length
+}
+
+// BytesNoCopy returns a []byte that shares memory with the ArrayBuffer's
+// backing store. It is only valid for as long as the ArrayBuffer itself is
+// reachable; callers that need the data to outlive the buffer should use
+// Bytes() instead.
+func (ab *ArrayBuffer) BytesNoCopy() []byte {
+	ab.cx.rt.lock()
+	defer ab.cx.rt.unlock()
+
+	data, length := ab.rawData()
+	if data == nil {
+		return nil
+	}
+
+	return (*[1 << 30]byte)(data)[:length:length]
+}
+
+// Bytes returns a copy of the ArrayBuffer's contents.
+func (ab *ArrayBuffer) Bytes() []byte {
+	noCopy := ab.BytesNoCopy()
+	if noCopy == nil {
+		return nil
+	}
+
+	b := make([]byte, len(noCopy))
+	copy(b, noCopy)
+	return b
+}
+
+// TypedArrayKind identifies the element type backing a typed-array view.
+type TypedArrayKind int
+
+const (
+	Int8ArrayKind TypedArrayKind = iota
+	Uint8ArrayKind
+	Float64ArrayKind
+)
+
+// Int8Array, Uint8Array and Float64Array are typed-array views over an
+// ArrayBuffer. They embed ArrayBuffer for its raw Bytes()/BytesNoCopy()
+// accessors, and add a typed accessor over the same backing store.
+type Int8Array struct{ ArrayBuffer }
+type Uint8Array struct{ ArrayBuffer }
+type Float64Array struct{ ArrayBuffer }
+
+// NewInt8Array creates a new Int8Array view over a fresh backing buffer of
+// the given element count.
+func (cx *Context) NewInt8Array(length int) *Int8Array {
+	cx.rt.lock()
+	defer cx.rt.unlock()
+
+	obj := C.JS_NewInt8Array(cx.jscx, C.uint32(length))
+	if obj == nil {
+		return nil
+	}
+	return &Int8Array{*newArrayBuffer(cx, obj)}
+}
+
+// NewUint8Array creates a new Uint8Array view over a fresh backing buffer
+// of the given element count.
+func (cx *Context) NewUint8Array(length int) *Uint8Array {
+	cx.rt.lock()
+	defer cx.rt.unlock()
+
+	obj := C.JS_NewUint8Array(cx.jscx, C.uint32(length))
+	if obj == nil {
+		return nil
+	}
+	return &Uint8Array{*newArrayBuffer(cx, obj)}
+}
+
+// NewFloat64Array creates a new Float64Array view over a fresh backing
+// buffer of the given element count.
+func (cx *Context) NewFloat64Array(length int) *Float64Array {
+	cx.rt.lock()
+	defer cx.rt.unlock()
+
+	obj := C.JS_NewFloat64Array(cx.jscx, C.uint32(length))
+	if obj == nil {
+		return nil
+	}
+	return &Float64Array{*newArrayBuffer(cx, obj)}
+}
+
+// Int8s returns a copy of the view's elements as []int8.
+func (a *Int8Array) Int8s() []int8 {
+	raw := a.Bytes()
+	out := make([]int8, len(raw))
+	for i, b := range raw {
+		out[i] = int8(b)
+	}
+	return out
+}
+
+// Uint8s returns a copy of the view's elements as []byte.
+func (a *Uint8Array) Uint8s() []byte {
+	return a.Bytes()
+}
+
+// Float64s returns a copy of the view's elements as []float64.
+func (a *Float64Array) Float64s() []float64 {
+	raw := a.BytesNoCopy()
+	n := len(raw) / 8
+	if n == 0 {
+		return nil
+	}
+
+	out := make([]float64, n)
+	src := (*[1 << 27]float64)(unsafe.Pointer(&raw[0]))[:n:n]
+	copy(out, src)
+	return out
+}
+
+// Kind reports the element kind of a typed-array view, via
+// JS_GetTypedArrayType, and false if ab wraps a plain ArrayBuffer with no
+// typed view over it.
+func (ab *ArrayBuffer) Kind() (TypedArrayKind, bool) {
+	ab.cx.rt.lock()
+	defer ab.cx.rt.unlock()
+
+	if C.JS_IsTypedArrayObject(ab.obj) != C.JS_TRUE {
+		return 0, false
+	}
+
+	switch C.JS_GetTypedArrayType(ab.obj) {
+	case C.JS_TYPED_ARRAY_INT8:
+		return Int8ArrayKind, true
+	case C.JS_TYPED_ARRAY_UINT8:
+		return Uint8ArrayKind, true
+	case C.JS_TYPED_ARRAY_FLOAT64:
+		return Float64ArrayKind, true
+	default:
+		return 0, false
+	}
+}
+
+// ToArrayBuffer reports whether v wraps a SpiderMonkey ArrayBuffer or
+// typed-array object, returning the underlying buffer if so. This is the
+// raw-bytes escape hatch Unmarshal's []byte decoding uses; callers that
+// want the kind-tagged typed-array view instead should use ToTypedArray.
+func (v *Value) ToArrayBuffer() (*ArrayBuffer, bool) {
+	o := v.ToObject()
+	if o == nil {
+		return nil, false
+	}
+
+	if C.JS_IsArrayBufferObject(o.obj) == C.JS_TRUE || C.JS_IsTypedArrayObject(o.obj) == C.JS_TRUE {
+		return newArrayBuffer(v.cx, o.obj), true
+	}
+
+	return nil, false
+}
+
+// ToTypedArray detects typed arrays via JSAPI and returns the matching
+// kind-tagged wrapper (*Int8Array, *Uint8Array or *Float64Array) instead of
+// the plain *ArrayBuffer ToArrayBuffer returns, so callers don't have to
+// know in advance which view a script handed back. A plain ArrayBuffer, or a
+// typed array of any other element type, is returned as *ArrayBuffer.
+func (v *Value) ToTypedArray() (interface{}, bool) {
+	ab, ok := v.ToArrayBuffer()
+	if !ok {
+		return nil, false
+	}
+
+	kind, ok := ab.Kind()
+	if !ok {
+		return ab, true // plain ArrayBuffer, no typed view
+	}
+
+	switch kind {
+	case Int8ArrayKind:
+		return &Int8Array{*ab}, true
+	case Uint8ArrayKind:
+		return &Uint8Array{*ab}, true
+	case Float64ArrayKind:
+		return &Float64Array{*ab}, true
+	default:
+		return ab, true
+	}
+}
+
+// GetArrayBuffer returns the named property as an ArrayBuffer if it is a
+// SpiderMonkey typed array or array-buffer object.
+func (o *Object) GetArrayBuffer(name string) (*ArrayBuffer, bool) {
+	if v := o.GetProperty(name); v != nil {
+		return v.ToArrayBuffer()
+	}
+	return nil, false
+}
+
+// GetArrayBuffer returns the element at index as an ArrayBuffer if it is a
+// SpiderMonkey typed array or array-buffer object.
+func (a *Array) GetArrayBuffer(index int) (*ArrayBuffer, bool) {
+	if v := a.GetElement(index); v != nil {
+		return v.ToArrayBuffer()
+	}
+	return nil, false
+}
@@ -0,0 +1,166 @@
+package monkey
+
+/*
+#include "monkey.h"
+*/
+import "C"
+import "unsafe"
+
+// ObjectHandler is the catch-all trap installed with Object.SetHandler. It
+// carries no methods of its own: implement whichever of ObjectGetter,
+// ObjectSetter, ObjectHaser, ObjectDeleter and ObjectKeyEnumerator below a
+// given handler needs, the way http.Flusher or io.Closer are optionally
+// implemented alongside a base interface. A handler that only implements
+// ObjectGetter, say, behaves like a read-only resolve hook.
+type ObjectHandler interface{}
+
+// ObjectGetter backs the JSClass getProperty/resolve hooks.
+type ObjectGetter interface {
+	Get(name string) (*Value, bool)
+}
+
+// ObjectSetter backs the JSClass setProperty hook.
+type ObjectSetter interface {
+	Set(name string, v *Value) bool
+}
+
+// ObjectHaser backs property-existence checks ("in" / resolve probing).
+type ObjectHaser interface {
+	Has(name string) bool
+}
+
+// ObjectDeleter backs the JSClass delProperty hook.
+type ObjectDeleter interface {
+	Delete(name string) bool
+}
+
+// ObjectKeyEnumerator backs the JSClass enumerate hook, used for for-in
+// loops and Object.keys() over a handler-backed object.
+type ObjectKeyEnumerator interface {
+	OwnKeys() []string
+}
+
+// SetHandler installs h as o's property trap. It reports false and leaves
+// o untouched if o wasn't created by NewHandlerObject, since only
+// go_handler_class's resolve/getProperty/setProperty/delProperty/enumerate
+// hooks actually dispatch into call_go_handler_*; installing a handler on a
+// plain object would otherwise be silently inert.
+func (o *Object) SetHandler(h ObjectHandler) bool {
+	o.cx.rt.lock()
+	defer o.cx.rt.unlock()
+
+	if C.JS_GetClass(o.cx.jscx, o.obj) != &C.go_handler_class {
+		return false
+	}
+
+	o.handler = h
+	return true
+}
+
+// NewHandlerObject creates an object backed by go_handler_class, whose
+// hooks forward to h, so dynamic namespaces (e.g. a db object that lazily
+// materializes table accessors) don't need every property predefined. h
+// may be replaced later with SetHandler.
+func (cx *Context) NewHandlerObject(h ObjectHandler) *Object {
+	cx.rt.lock()
+	defer cx.rt.unlock()
+
+	obj := C.JS_NewObject(cx.jscx, &C.go_handler_class, nil, nil)
+	if obj == nil {
+		return nil
+	}
+
+	result := newObject(cx, obj)
+	result.handler = h
+	return result
+}
+
+//export call_go_handler_resolve
+func call_go_handler_resolve(obj unsafe.Pointer, name *C.char) C.JSBool {
+	o := (*Object)(obj)
+
+	if h, ok := o.handler.(ObjectHaser); ok {
+		if h.Has(C.GoString(name)) {
+			return C.JS_TRUE
+		}
+		return C.JS_FALSE
+	}
+
+	if g, ok := o.handler.(ObjectGetter); ok {
+		if _, found := g.Get(C.GoString(name)); found {
+			return C.JS_TRUE
+		}
+	}
+
+	return C.JS_FALSE
+}
+
+//export call_go_handler_get
+func call_go_handler_get(obj unsafe.Pointer, name *C.char, val *C.jsval) C.JSBool {
+	o := (*Object)(obj)
+
+	g, ok := o.handler.(ObjectGetter)
+	if !ok {
+		return C.JS_FALSE
+	}
+
+	v, found := g.Get(C.GoString(name))
+	if !found || v == nil {
+		*val = C.JSVAL_VOID
+		return C.JS_TRUE
+	}
+
+	*val = v.val
+	return C.JS_TRUE
+}
+
+//export call_go_handler_set
+func call_go_handler_set(obj unsafe.Pointer, name *C.char, val *C.jsval) C.JSBool {
+	o := (*Object)(obj)
+
+	s, ok := o.handler.(ObjectSetter)
+	if !ok {
+		return C.JS_FALSE
+	}
+
+	if !s.Set(C.GoString(name), newValue(o.cx, *val)) {
+		return C.JS_FALSE
+	}
+	return C.JS_TRUE
+}
+
+//export call_go_handler_delete
+func call_go_handler_delete(obj unsafe.Pointer, name *C.char) C.JSBool {
+	o := (*Object)(obj)
+
+	d, ok := o.handler.(ObjectDeleter)
+	if !ok {
+		return C.JS_TRUE // nothing to refuse deleting
+	}
+
+	if !d.Delete(C.GoString(name)) {
+		return C.JS_FALSE
+	}
+	return C.JS_TRUE
+}
+
+//export call_go_handler_enumerate
+func call_go_handler_enumerate(obj unsafe.Pointer) **C.char {
+	o := (*Object)(obj)
+
+	e, ok := o.handler.(ObjectKeyEnumerator)
+	if !ok {
+		return nil
+	}
+
+	keys := e.OwnKeys()
+	cKeys := C.malloc_cstring_array(C.int(len(keys) + 1))
+	arr := (*[1 << 20]*C.char)(unsafe.Pointer(cKeys))[: len(keys)+1 : len(keys)+1]
+
+	for i, k := range keys {
+		arr[i] = C.CString(k)
+	}
+	arr[len(keys)] = nil
+
+	return cKeys
+}
@@ -0,0 +1,41 @@
+package monkey
+
+import "testing"
+
+type mapHandler map[string]*Value
+
+func (h mapHandler) Get(name string) (*Value, bool) { v, ok := h[name]; return v, ok }
+func (h mapHandler) Set(name string, v *Value) bool { h[name] = v; return true }
+
+func TestSetHandlerRejectsPlainObject(t *testing.T) {
+	cx := newTestContext()
+	plain := cx.NewObject()
+
+	if plain.SetHandler(mapHandler{}) {
+		t.Fatal("SetHandler succeeded on an object not created by NewHandlerObject")
+	}
+}
+
+func TestNewHandlerObjectInstallsHandler(t *testing.T) {
+	cx := newTestContext()
+	h := mapHandler{"greeting": cx.String("hi")}
+
+	o := cx.NewHandlerObject(h)
+	if o == nil {
+		t.Fatal("NewHandlerObject returned nil")
+	}
+
+	g, isGetter := o.handler.(ObjectGetter)
+	if !isGetter {
+		t.Fatalf("NewHandlerObject installed handler %T, want the mapHandler passed in", o.handler)
+	}
+
+	got, ok := g.Get("greeting")
+	if !ok || got.ToString() != "hi" {
+		t.Fatalf("handler lookup for \"greeting\" = %v, %v", got, ok)
+	}
+
+	if !o.SetHandler(mapHandler{"replaced": cx.String("yes")}) {
+		t.Fatal("SetHandler on a NewHandlerObject-created object should succeed")
+	}
+}
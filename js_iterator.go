@@ -0,0 +1,146 @@
+package monkey
+
+/*
+#include "monkey.h"
+*/
+import "C"
+import "unsafe"
+
+// ForEach walks the array's elements, locking the runtime once instead of
+// once per GetElement call the way a manual `for i := 0; i < a.GetLength()`
+// loop does. fn is called with each index and value in order; returning
+// false stops the walk early.
+func (a *Array) ForEach(fn func(i int, v *Value) bool) {
+	a.cx.rt.lock()
+	defer a.cx.rt.unlock()
+
+	var length C.jsuint
+	if C.JS_GetArrayLength(a.cx.jscx, a.obj, &length) != C.JS_TRUE {
+		return
+	}
+
+	for i := 0; i < int(length); i++ {
+		var rval C.jsval
+		if C.JS_GetElement(a.cx.jscx, a.obj, C.jsint(i), &rval) != C.JS_TRUE {
+			continue
+		}
+
+		if !fn(i, newValue(a.cx, rval)) {
+			return
+		}
+	}
+}
+
+// ForEachProperty walks the object's own enumerable properties via
+// JS_NewPropertyIterator/JS_NextProperty, locking the runtime once for the
+// whole walk. Returning false from fn stops the walk early.
+func (o *Object) ForEachProperty(fn func(name string, v *Value) bool) {
+	o.cx.rt.lock()
+	defer o.cx.rt.unlock()
+
+	iter := C.JS_NewPropertyIterator(o.cx.jscx, o.obj)
+	if iter == nil {
+		return
+	}
+
+	for {
+		var id C.jsid
+		if C.JS_NextProperty(o.cx.jscx, iter, &id) != C.JS_TRUE {
+			return
+		}
+
+		if id == C.JSID_VOID {
+			return // end of iteration
+		}
+
+		if C.JSID_IS_SYMBOL(id) == C.JS_TRUE {
+			continue // Symbol-keyed properties have no string name to hand fn
+		}
+
+		var nameVal C.jsval
+		if C.JS_IdToValue(o.cx.jscx, id, &nameVal) != C.JS_TRUE {
+			continue
+		}
+		name := newValue(o.cx, nameVal).ToString()
+
+		cname := C.CString(name)
+		var rval C.jsval
+		getOk := C.JS_GetProperty(o.cx.jscx, o.obj, cname, &rval) == C.JS_TRUE
+		C.free(unsafe.Pointer(cname))
+		if !getOk {
+			continue
+		}
+
+		if !fn(name, newValue(o.cx, rval)) {
+			return
+		}
+	}
+}
+
+// NewIterator returns a JS object implementing the iterator protocol
+// (a .next() method returning {value, done}) over o's own properties, so a
+// Go-defined collection handed to a script works inside `for (const x of
+// obj)`. The property names are captured once, via Keys(), when the
+// iterator is created; each call to .next() then fetches the value of the
+// next captured name.
+func (o *Object) NewIterator() *Value {
+	o.cx.rt.lock()
+	defer o.cx.rt.unlock()
+
+	iterObj := C.JS_NewObject(o.cx.jscx, nil, nil, nil)
+	if iterObj == nil {
+		return nil
+	}
+
+	it := newObject(o.cx, iterObj)
+	it.state = newPropertyWalk(o)
+
+	cname := C.CString("next")
+	defer C.free(unsafe.Pointer(cname))
+
+	if C.JS_DefineFunction(o.cx.jscx, it.obj, cname, C.the_go_iterator_next_callback, 0, 0) == nil {
+		return nil
+	}
+
+	return it.ToValue()
+}
+
+// propertyWalk is the paused state behind one NewIterator()'s .next() calls.
+type propertyWalk struct {
+	o     *Object
+	names []string
+	pos   int
+}
+
+func newPropertyWalk(o *Object) *propertyWalk {
+	return &propertyWalk{o: o, names: o.Keys()}
+}
+
+//export call_go_iterator_next
+func call_go_iterator_next(obj unsafe.Pointer, vp *C.jsval) C.JSBool {
+	it := (*Object)(obj)
+
+	walk, ok := it.state.(*propertyWalk)
+	if !ok {
+		return C.JS_FALSE
+	}
+
+	result := it.cx.NewObject()
+
+	if walk.pos >= len(walk.names) {
+		result.SetBoolean("done", true)
+		C.SET_RVAL(it.cx.jscx, vp, result.ToValue().val)
+		return C.JS_TRUE
+	}
+
+	name := walk.names[walk.pos]
+	walk.pos++
+
+	result.SetBoolean("done", false)
+	if v := walk.o.GetProperty(name); v != nil {
+		result.SetProperty("value", v)
+	}
+
+	C.SET_RVAL(it.cx.jscx, vp, result.ToValue().val)
+	return C.JS_TRUE
+}
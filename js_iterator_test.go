@@ -0,0 +1,85 @@
+package monkey
+
+import "testing"
+
+func TestArrayForEach(t *testing.T) {
+	cx := newTestContext()
+	a := cx.NewArray(3)
+	a.SetInts([]int32{10, 20, 30})
+
+	var sum int32
+	var indices []int
+	a.ForEach(func(i int, v *Value) bool {
+		n, _ := v.ToInt()
+		sum += n
+		indices = append(indices, i)
+		return true
+	})
+
+	if sum != 60 {
+		t.Fatalf("ForEach summed to %d, want 60", sum)
+	}
+	if len(indices) != 3 {
+		t.Fatalf("ForEach visited %d elements, want 3", len(indices))
+	}
+}
+
+func TestArrayForEachStopsEarly(t *testing.T) {
+	cx := newTestContext()
+	a := cx.NewArray(3)
+	a.SetInts([]int32{1, 2, 3})
+
+	visited := 0
+	a.ForEach(func(i int, v *Value) bool {
+		visited++
+		return i < 0 // stop after the first element
+	})
+
+	if visited != 1 {
+		t.Fatalf("ForEach visited %d elements before stopping, want 1", visited)
+	}
+}
+
+func TestObjectForEachPropertySkipsSymbols(t *testing.T) {
+	cx := newTestContext()
+	o := cx.NewObject()
+	o.SetString("name", "Ada")
+
+	sym := cx.NewSymbol("hidden")
+	o.DefineProperty(SymbolKey(sym), cx.String("secret"), nil, nil, JSPROP_ENUMERATE)
+
+	var names []string
+	o.ForEachProperty(func(name string, v *Value) bool {
+		names = append(names, name)
+		return true
+	})
+
+	if len(names) != 1 || names[0] != "name" {
+		t.Fatalf("ForEachProperty visited %v, want only [\"name\"]", names)
+	}
+}
+
+func TestNewIteratorWalksProperties(t *testing.T) {
+	cx := newTestContext()
+	o := cx.NewObject()
+	o.SetString("a", "1")
+	o.SetString("b", "2")
+
+	iterVal := o.NewIterator()
+	if iterVal == nil {
+		t.Fatal("NewIterator returned nil")
+	}
+
+	iterObj := iterVal.ToObject()
+	if iterObj == nil {
+		t.Fatal("NewIterator's Value did not convert back to an Object")
+	}
+
+	walk, ok := iterObj.state.(*propertyWalk)
+	if !ok {
+		t.Fatalf("iterator object's state is %T, want *propertyWalk", iterObj.state)
+	}
+	if len(walk.names) != 2 {
+		t.Fatalf("propertyWalk captured %d names, want 2", len(walk.names))
+	}
+}
@@ -0,0 +1,443 @@
+package monkey
+
+/*
+#include "monkey.h"
+*/
+import "C"
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MarshalValue converts a Go value into a JS Value using reflection,
+// recursing through structs, maps, slices and pointers so callers don't
+// have to hand-roll SetInt/SetString/GetObject loops field by field.
+//
+// Struct fields are matched to property names via a `js:"name,omitempty"`
+// tag; the Go field name is used when no tag is present, and a `js:"-"`
+// tag skips the field. time.Time becomes a JS Date, []byte becomes an
+// ArrayBuffer, and map[string]T becomes a plain Object with keys written
+// in sorted order so the result is reproducible.
+func (cx *Context) MarshalValue(v interface{}) (*Value, error) {
+	return cx.marshal(reflect.ValueOf(v), make(map[uintptr]*Object))
+}
+
+func (cx *Context) marshal(rv reflect.Value, seen map[uintptr]*Object) (*Value, error) {
+	if !rv.IsValid() {
+		return cx.nullValue(), nil
+	}
+
+	if rv.Kind() == reflect.Interface {
+		return cx.marshal(rv.Elem(), seen)
+	}
+
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return cx.nullValue(), nil
+		}
+		if o, ok := seen[rv.Pointer()]; ok {
+			return o.ToValue(), nil
+		}
+		return cx.marshal(rv.Elem(), seen)
+	}
+
+	if t, ok := rv.Interface().(time.Time); ok {
+		return cx.dateValue(t), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		return cx.marshalStruct(rv, seen)
+	case reflect.Map:
+		return cx.marshalMap(rv, seen)
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return cx.NewArrayBufferFromBytes(rv.Bytes()).ToValue(), nil
+		}
+		return cx.marshalSlice(rv, seen)
+	case reflect.Array:
+		return cx.marshalSlice(rv, seen)
+	case reflect.String:
+		return cx.String(rv.String()), nil
+	case reflect.Bool:
+		return cx.Boolean(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cx.Int(int32(rv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := rv.Uint()
+		if u > math.MaxInt32 {
+			// Wider than Int's jsval int32 can hold without wrapping to
+			// negative; JS numbers are doubles, so fall back to that.
+			return cx.Number(float64(u)), nil
+		}
+		return cx.Int(int32(u)), nil
+	case reflect.Float32, reflect.Float64:
+		return cx.Number(rv.Float()), nil
+	default:
+		return nil, fmt.Errorf("monkey: cannot marshal Go value of kind %s", rv.Kind())
+	}
+}
+
+func (cx *Context) marshalStruct(rv reflect.Value, seen map[uintptr]*Object) (*Value, error) {
+	o := cx.NewObject()
+	if rv.CanAddr() {
+		seen[rv.Addr().Pointer()] = o
+	}
+
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+
+		name, omitempty, skip := jsTag(field)
+		if skip {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if omitempty && isEmptyValue(fv) {
+			continue
+		}
+
+		fval, err := cx.marshal(fv, seen)
+		if err != nil {
+			return nil, fmt.Errorf("monkey: field %s.%s: %v", t.Name(), field.Name, err)
+		}
+
+		o.SetProperty(name, fval)
+	}
+
+	return o.ToValue(), nil
+}
+
+func (cx *Context) marshalMap(rv reflect.Value, seen map[uintptr]*Object) (*Value, error) {
+	o := cx.NewObject()
+
+	keys := rv.MapKeys()
+	names := make([]string, len(keys))
+	byName := make(map[string]reflect.Value, len(keys))
+	for i, k := range keys {
+		names[i] = fmt.Sprintf("%v", k.Interface())
+		byName[names[i]] = rv.MapIndex(k)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v, err := cx.marshal(byName[name], seen)
+		if err != nil {
+			return nil, fmt.Errorf("monkey: map key %q: %v", name, err)
+		}
+		o.SetProperty(name, v)
+	}
+
+	return o.ToValue(), nil
+}
+
+func (cx *Context) marshalSlice(rv reflect.Value, seen map[uintptr]*Object) (*Value, error) {
+	n := rv.Len()
+
+	a := cx.NewArray(n)
+	a.SetLength(n)
+
+	for i := 0; i < n; i++ {
+		v, err := cx.marshal(rv.Index(i), seen)
+		if err != nil {
+			return nil, fmt.Errorf("monkey: index %d: %v", i, err)
+		}
+		a.SetElement(i, v)
+	}
+
+	return a.ToValue(), nil
+}
+
+func (cx *Context) nullValue() *Value {
+	return newValue(cx, C.JSVAL_NULL)
+}
+
+func (cx *Context) dateValue(t time.Time) *Value {
+	cx.rt.lock()
+	defer cx.rt.unlock()
+
+	msec := float64(t.UnixNano()) / 1e6
+	obj := C.JS_NewDateObjectMsec(cx.jscx, C.jsdouble(msec))
+
+	return newObject(cx, obj).ToValue()
+}
+
+func jsTag(field reflect.StructField) (name string, omitempty bool, skip bool) {
+	tag := field.Tag.Get("js")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+
+	return name, omitempty, false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// Unmarshal converts a JS Value (an Object, Array, or primitive) into a Go
+// value, the inverse of MarshalValue. out must be a non-nil pointer.
+// Unmarshal dispatches on the Go field's reflect.Kind the way json.Unmarshal
+// does, and reports mismatches the same way ("cannot unmarshal JS string
+// into Go field Foo.Bar of type int32") rather than panicking.
+func (v *Value) Unmarshal(out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("monkey: Unmarshal requires a non-nil pointer")
+	}
+
+	return v.unmarshal(rv.Elem(), "")
+}
+
+func (v *Value) unmarshal(rv reflect.Value, path string) error {
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(v.ToString())
+		return nil
+	case reflect.Bool:
+		b, _ := v.ToBoolean()
+		rv.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := v.ToInt()
+		if !ok {
+			return unmarshalTypeError(v, rv, path)
+		}
+		rv.SetInt(int64(n))
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := v.ToInt()
+		if !ok || n < 0 {
+			return unmarshalTypeError(v, rv, path)
+		}
+		rv.SetUint(uint64(n))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		n, ok := v.ToNumber()
+		if !ok {
+			return unmarshalTypeError(v, rv, path)
+		}
+		rv.SetFloat(n)
+		return nil
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return v.unmarshal(rv.Elem(), path)
+	case reflect.Struct:
+		if _, ok := rv.Interface().(time.Time); ok {
+			tv, ok := v.toTime()
+			if !ok {
+				return unmarshalTypeError(v, rv, path)
+			}
+			rv.Set(reflect.ValueOf(tv))
+			return nil
+		}
+		return v.unmarshalStruct(rv, path)
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			ab, ok := v.ToArrayBuffer()
+			if !ok {
+				return unmarshalTypeError(v, rv, path)
+			}
+			rv.SetBytes(ab.Bytes())
+			return nil
+		}
+		return v.unmarshalSlice(rv, path)
+	case reflect.Map:
+		return v.unmarshalMap(rv, path)
+	case reflect.Interface:
+		if rv.NumMethod() == 0 {
+			rv.Set(reflect.ValueOf(v.toInterface()))
+			return nil
+		}
+	}
+
+	return fmt.Errorf("monkey: cannot unmarshal into unsupported Go type %s", rv.Type())
+}
+
+func (v *Value) unmarshalStruct(rv reflect.Value, path string) error {
+	o := v.ToObject()
+	if o == nil {
+		return unmarshalTypeError(v, rv, path)
+	}
+
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name, _, skip := jsTag(field)
+		if skip {
+			continue
+		}
+
+		pv := o.GetProperty(name)
+		if pv == nil {
+			continue
+		}
+
+		fieldPath := fmt.Sprintf("%s.%s", t.Name(), field.Name)
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+
+		if err := pv.unmarshal(rv.Field(i), fieldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *Value) unmarshalSlice(rv reflect.Value, path string) error {
+	a := v.ToArray()
+	if a == nil {
+		return unmarshalTypeError(v, rv, path)
+	}
+
+	n := a.GetLength()
+	if n < 0 {
+		return unmarshalTypeError(v, rv, path)
+	}
+
+	out := reflect.MakeSlice(rv.Type(), n, n)
+	for i := 0; i < n; i++ {
+		ev := a.GetElement(i)
+		if ev == nil {
+			continue
+		}
+		if err := ev.unmarshal(out.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+
+	rv.Set(out)
+	return nil
+}
+
+func (v *Value) unmarshalMap(rv reflect.Value, path string) error {
+	if rv.Type().Key().Kind() != reflect.String {
+		return unmarshalTypeError(v, rv, path)
+	}
+
+	o := v.ToObject()
+	if o == nil {
+		return unmarshalTypeError(v, rv, path)
+	}
+
+	out := reflect.MakeMap(rv.Type())
+	for _, name := range o.Keys() {
+		pv := o.GetProperty(name)
+		if pv == nil {
+			continue
+		}
+
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		if err := pv.unmarshal(elem, path+"."+name); err != nil {
+			return err
+		}
+
+		out.SetMapIndex(reflect.ValueOf(name), elem)
+	}
+
+	rv.Set(out)
+	return nil
+}
+
+// toInterface does a best-effort conversion for interface{}-typed
+// destinations, where there is no Go type to guide the decode. It checks
+// v's actual JS type rather than trying ToNumber() first, since JS's
+// ToNumber coercion happily turns a bool into 1/0 and would otherwise
+// silently turn every JS boolean into a Go float64.
+func (v *Value) toInterface() interface{} {
+	switch {
+	case C.JSVAL_IS_BOOLEAN(v.val) == C.JS_TRUE:
+		b, _ := v.ToBoolean()
+		return b
+	case C.JSVAL_IS_STRING(v.val) == C.JS_TRUE:
+		return v.ToString()
+	case C.JSVAL_IS_INT(v.val) == C.JS_TRUE, C.JSVAL_IS_DOUBLE(v.val) == C.JS_TRUE:
+		n, _ := v.ToNumber()
+		return n
+	default:
+		return v.ToString()
+	}
+}
+
+// toTime reports whether v is a JS Date object, returning its value as a
+// time.Time if so.
+func (v *Value) toTime() (time.Time, bool) {
+	o := v.ToObject()
+	if o == nil {
+		return time.Time{}, false
+	}
+
+	o.cx.rt.lock()
+	defer o.cx.rt.unlock()
+
+	if C.JS_ObjectIsDate(o.cx.jscx, o.obj) != C.JS_TRUE {
+		return time.Time{}, false
+	}
+
+	var msec C.jsdouble
+	if C.JS_DateObjectMsec(o.cx.jscx, o.obj, &msec) != C.JS_TRUE {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, int64(float64(msec)*1e6)), true
+}
+
+func unmarshalTypeError(v *Value, rv reflect.Value, path string) error {
+	if path == "" {
+		path = "value"
+	}
+
+	jsType := "value"
+	switch {
+	case C.JSVAL_IS_BOOLEAN(v.val) == C.JS_TRUE:
+		jsType = "boolean"
+	case C.JSVAL_IS_STRING(v.val) == C.JS_TRUE:
+		jsType = "string"
+	case C.JSVAL_IS_INT(v.val) == C.JS_TRUE, C.JSVAL_IS_DOUBLE(v.val) == C.JS_TRUE:
+		jsType = "number"
+	}
+
+	return fmt.Errorf("monkey: cannot unmarshal JS %s into Go field %s of type %s", jsType, path, rv.Type())
+}
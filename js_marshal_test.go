@@ -0,0 +1,92 @@
+package monkey
+
+import (
+	"math"
+	"testing"
+)
+
+// newTestContext mirrors newBenchArray's setup in js_array_bench_test.go: a
+// throwaway runtime/context pair good for one test's lifetime.
+func newTestContext() *Context {
+	rt := NewRuntime(8 * 1024 * 1024)
+	return rt.NewContext(8192)
+}
+
+type marshalPerson struct {
+	Name    string `js:"name"`
+	Age     int32  `js:"age"`
+	Hidden  string `js:"-"`
+	skipped string
+}
+
+func TestMarshalUnmarshalStruct(t *testing.T) {
+	cx := newTestContext()
+
+	in := marshalPerson{Name: "Ada", Age: 36, Hidden: "nope"}
+	v, err := cx.MarshalValue(in)
+	if err != nil {
+		t.Fatalf("MarshalValue: %v", err)
+	}
+
+	var out marshalPerson
+	if err := v.Unmarshal(&out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if out.Name != in.Name || out.Age != in.Age {
+		t.Fatalf("got %+v, want name/age from %+v", out, in)
+	}
+	if out.Hidden != "" {
+		t.Fatalf("js:\"-\" field Hidden should not round-trip, got %q", out.Hidden)
+	}
+}
+
+func TestUnmarshalUintRejectsNegative(t *testing.T) {
+	cx := newTestContext()
+
+	neg := cx.Int(-1)
+
+	var out uint32
+	if err := neg.Unmarshal(&out); err == nil {
+		t.Fatal("Unmarshal into uint32 accepted a negative JS number")
+	}
+}
+
+func TestMarshalUintOverflowUsesNumber(t *testing.T) {
+	cx := newTestContext()
+
+	var big uint64 = math.MaxInt32 + 1000
+	v, err := cx.MarshalValue(big)
+	if err != nil {
+		t.Fatalf("MarshalValue: %v", err)
+	}
+
+	n, ok := v.ToNumber()
+	if !ok || n != float64(big) {
+		t.Fatalf("marshaled uint64 %d overflowing int32 did not round-trip through ToNumber: %v, %v", big, n, ok)
+	}
+}
+
+func TestUnmarshalMapRejectsNonStringKeys(t *testing.T) {
+	cx := newTestContext()
+	o := cx.NewObject()
+	o.SetInt("1", 10)
+
+	out := map[int]int{}
+	if err := o.ToValue().Unmarshal(&out); err == nil {
+		t.Fatal("Unmarshal into map[int]int should have been rejected, map keys must be string-kinded")
+	}
+}
+
+func TestToInterfaceDistinguishesBoolFromNumber(t *testing.T) {
+	cx := newTestContext()
+
+	var dst interface{}
+	if err := cx.Boolean(true).Unmarshal(&dst); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if _, ok := dst.(bool); !ok {
+		t.Fatalf("JS boolean decoded into interface{} as %T, want bool", dst)
+	}
+}
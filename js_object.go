@@ -14,18 +14,20 @@ type Object struct {
 	cx      *Context
 	obj     *C.JSObject
 	funcs   map[string]JsFunc
-	getters map[string]JsPropertyGetter
-	setters map[string]JsPropertySetter
+	handler ObjectHandler
+	state   interface{} // private per-object state, e.g. a paused NewIterator() walk
+	cookies []uintptr   // propertyBinders entries DefineProperty registered for this object
 }
 
 // Add the JSObject to the garbage collector's root set.
 // See: https://developer.mozilla.org/en-US/docs/Mozilla/Projects/SpiderMonkey/JSAPI_reference/JS_AddRoot
 func newObject(cx *Context, obj *C.JSObject) *Object {
-	result := &Object{cx, obj, nil, nil, nil}
+	result := &Object{cx, obj, nil, nil, nil, nil}
 
 	C.JS_AddObjectRoot(cx.jscx, &result.obj)
 
 	runtime.SetFinalizer(result, func(o *Object) {
+		purgePropertyBindings(o)
 		C.JS_RemoveObjectRoot(o.cx.jscx, &o.obj)
 	})
 
@@ -84,71 +86,8 @@ const (
 type JsPropertyGetter func(o *Object) *Value
 type JsPropertySetter func(o *Object, v *Value)
 
-//export call_go_getter
-func call_go_getter(obj unsafe.Pointer, name *C.char, val *C.jsval) C.JSBool {
-	o := (*Object)(obj)
-	if o.getters != nil {
-		if v := o.getters[C.GoString(name)](o); v != nil {
-			*val = v.val
-			return C.JS_TRUE
-		}
-	}
-	return C.JS_FALSE
-}
-
-//export call_go_setter
-func call_go_setter(obj unsafe.Pointer, name *C.char, val *C.jsval) C.JSBool {
-	o := (*Object)(obj)
-	if o.setters != nil {
-		o.setters[C.GoString(name)](o, newValue(o.cx, *val))
-		return C.JS_TRUE
-	}
-	return C.JS_FALSE
-}
-
-func (o *Object) DefineProperty(name string, value *Value, getter JsPropertyGetter, setter JsPropertySetter, attrs JsPropertyAttrs) bool {
-	o.cx.rt.lock()
-	defer o.cx.rt.unlock()
-
-	if C.JS_IsArrayObject(o.cx.jscx, o.obj) == C.JS_TRUE {
-		panic("Could't define property on array.")
-	}
-
-	cname := C.CString(name)
-	defer C.free(unsafe.Pointer(cname))
-
-	var r C.JSBool
-
-	if getter != nil && setter != nil {
-		r = C.JS_DefineProperty(o.cx.jscx, o.obj, cname, value.val, C.the_go_getter_callback, C.the_go_setter_callback, C.uintN(uint(attrs))|C.JSPROP_SHARED)
-	} else if getter != nil && setter == nil {
-		r = C.JS_DefineProperty(o.cx.jscx, o.obj, cname, value.val, C.the_go_getter_callback, nil, C.uintN(uint(attrs)))
-	} else if getter == nil && setter != nil {
-		r = C.JS_DefineProperty(o.cx.jscx, o.obj, cname, value.val, nil, C.the_go_setter_callback, C.uintN(uint(attrs)))
-	} else {
-		panic("The getter and setter both nil")
-	}
-
-	if r == C.JS_TRUE {
-		if getter != nil {
-			if o.getters == nil {
-				o.getters = make(map[string]JsPropertyGetter)
-			}
-			o.getters[name] = getter
-		}
-
-		if setter != nil {
-			if o.setters == nil {
-				o.setters = make(map[string]JsPropertySetter)
-			}
-			o.setters[name] = setter
-		}
-
-		return true
-	}
-
-	return false
-}
+// DefineProperty is implemented in js_property.go, which also holds the
+// PropertyKey/Symbol plumbing it depends on.
 
 //export call_go_obj_func
 func call_go_obj_func(op unsafe.Pointer, name *C.char, argc C.uintN, vp *C.jsval) C.JSBool {
@@ -262,3 +201,37 @@ func (o *Object) GetArray(name string) *Array {
 func (o *Object) SetArray(name string, o2 *Array) bool {
 	return o.SetProperty(name, o2.ToValue())
 }
+
+// Keys returns the object's own enumerable property names, in enumeration
+// order. It wraps JS_Enumerate, which nothing in this package exposed
+// before, and is the primitive map-decoding in Unmarshal is built on.
+func (o *Object) Keys() []string {
+	o.cx.rt.lock()
+	defer o.cx.rt.unlock()
+
+	ids := C.JS_Enumerate(o.cx.jscx, o.obj)
+	if ids == nil {
+		return nil
+	}
+	defer C.JS_DestroyIdArray(o.cx.jscx, ids)
+
+	n := int(ids.length)
+	keys := make([]string, 0, n)
+
+	for i := 0; i < n; i++ {
+		id := C.GET_ID_ARRAY_ELEMENT(ids, C.int(i))
+
+		if C.JSID_IS_SYMBOL(id) == C.JS_TRUE {
+			continue // Keys() only reports string-representable names, not Symbols
+		}
+
+		var v C.jsval
+		if C.JS_IdToValue(o.cx.jscx, id, &v) != C.JS_TRUE {
+			continue
+		}
+
+		keys = append(keys, newValue(o.cx, v).ToString())
+	}
+
+	return keys
+}
@@ -0,0 +1,229 @@
+package monkey
+
+/*
+#include "monkey.h"
+
+// A bare Go uintptr->unsafe.Pointer conversion trips go vet's unsafeptr
+// check even when, as here, the "pointer" is really just an opaque cookie
+// round-tripped through JS_SetPrivate's void* slot. Doing the cast on the C
+// side keeps Go from ever seeing a Pointer manufactured from an integer.
+static void *monkey_cookie_to_private(uintptr_t cookie) {
+	return (void *)cookie;
+}
+*/
+import "C"
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Symbol is a unique, non-string property key, analogous to the string
+// atoms SpiderMonkey interns for named properties.
+type Symbol struct {
+	cx  *Context
+	obj *C.JSObject
+}
+
+func newSymbol(cx *Context, obj *C.JSObject) *Symbol {
+	result := &Symbol{cx, obj}
+
+	C.JS_AddObjectRoot(cx.jscx, &result.obj)
+
+	runtime.SetFinalizer(result, func(s *Symbol) {
+		C.JS_RemoveObjectRoot(s.cx.jscx, &s.obj)
+	})
+
+	return result
+}
+
+// NewSymbol creates a fresh Symbol, unique even from another Symbol created
+// with the same description, suitable for use as a PropertyKey.
+func (cx *Context) NewSymbol(description string) *Symbol {
+	cx.rt.lock()
+	defer cx.rt.unlock()
+
+	cdesc := C.CString(description)
+	defer C.free(unsafe.Pointer(cdesc))
+
+	obj := C.JS_NewSymbol(cx.jscx, cdesc)
+	if obj == nil {
+		return nil
+	}
+
+	return newSymbol(cx, obj)
+}
+
+// PropertyKey names a property passed to DefineProperty: either a string or
+// a Symbol. Build one with StringKey or SymbolKey.
+type PropertyKey struct {
+	name string
+	sym  *Symbol
+}
+
+func StringKey(name string) PropertyKey { return PropertyKey{name: name} }
+func SymbolKey(s *Symbol) PropertyKey   { return PropertyKey{sym: s} }
+
+// toId resolves the key to a jsid via JS_ValueToId, the same path
+// SpiderMonkey itself uses internally for both string and symbol keys.
+// A numeric string (e.g. "3") naturally resolves to an array-index id,
+// which is why DefineProperty no longer needs to special-case arrays.
+func (k PropertyKey) toId(cx *Context) C.jsid {
+	var jsv C.jsval
+
+	if k.sym != nil {
+		jsv = C.OBJECT_TO_JSVAL(k.sym.obj)
+	} else {
+		cname := C.CString(k.name)
+		defer C.free(unsafe.Pointer(cname))
+		jsv = C.STRING_TO_JSVAL(C.JS_NewStringCopyZ(cx.jscx, cname))
+	}
+
+	var id C.jsid
+	C.JS_ValueToId(cx.jscx, jsv, &id)
+	return id
+}
+
+// propertyBinding pairs the getter/setter closures an accessor property was
+// defined with back to the Object it was defined on. It deliberately holds
+// the owner as its raw *C.JSObject rather than our Go *Object wrapper: the
+// wrapper's own private data already points back to it (see newObject), and
+// going through that indirection instead of storing *Object directly here
+// means propertyBinders — a process-global map — never holds a strong Go
+// reference to the owner. Without that, the owner could never become
+// unreachable and its finalizer (which purges this object's cookies) would
+// never run.
+type propertyBinding struct {
+	ownerCx  *Context
+	ownerObj *C.JSObject
+	getter   JsPropertyGetter
+	setter   JsPropertySetter
+}
+
+func (b *propertyBinding) owner() *Object {
+	return (*Object)(C.JS_GetPrivate(b.ownerCx.jscx, b.ownerObj))
+}
+
+var (
+	bindingSeq      uint64
+	propertyBinders sync.Map // uintptr cookie -> *propertyBinding
+)
+
+// bindAccessor registers getter/setter under a fresh opaque cookie, records
+// it against owner so its finalizer can find it again, and returns it. A
+// redefinition of the same property name gets its own cookie rather than
+// colliding in a name-keyed map the way the old getters/setters maps on
+// Object did; the previous cookie stays in owner.cookies and
+// propertyBinders until purgePropertyBindings runs from owner's finalizer.
+func bindAccessor(owner *Object, getter JsPropertyGetter, setter JsPropertySetter) uintptr {
+	cookie := uintptr(atomic.AddUint64(&bindingSeq, 1))
+	propertyBinders.Store(cookie, &propertyBinding{owner.cx, owner.obj, getter, setter})
+	owner.cookies = append(owner.cookies, cookie)
+	return cookie
+}
+
+// purgePropertyBindings removes every propertyBinders entry o registered,
+// called from o's finalizer once o is otherwise unreachable so the global
+// map doesn't grow without bound over the life of the runtime.
+func purgePropertyBindings(o *Object) {
+	for _, cookie := range o.cookies {
+		propertyBinders.Delete(cookie)
+	}
+}
+
+// newAccessorFunc creates a JS function object to stand in for one half of
+// an accessor property, stashing cookie as that function object's private
+// data (the "hidden slot" call_go_property_getter/setter read back from).
+func newAccessorFunc(cx *Context, trampoline unsafe.Pointer, cookie uintptr) *C.JSObject {
+	fn := C.JS_NewFunction(cx.jscx, (*[0]byte)(trampoline), 0, 0, nil, nil)
+	obj := C.JS_GetFunctionObject(fn)
+	C.JS_SetPrivate(cx.jscx, obj, C.monkey_cookie_to_private(C.uintptr_t(cookie)))
+	return obj
+}
+
+//export call_go_property_getter
+func call_go_property_getter(cookie unsafe.Pointer, val *C.jsval) C.JSBool {
+	b, ok := propertyBinders.Load(uintptr(cookie))
+	if !ok {
+		return C.JS_FALSE
+	}
+
+	binding := b.(*propertyBinding)
+	if binding.getter == nil {
+		return C.JS_FALSE
+	}
+
+	owner := binding.owner()
+	if owner == nil {
+		return C.JS_FALSE
+	}
+
+	if v := binding.getter(owner); v != nil {
+		*val = v.val
+		return C.JS_TRUE
+	}
+
+	return C.JS_FALSE
+}
+
+//export call_go_property_setter
+func call_go_property_setter(cookie unsafe.Pointer, val *C.jsval) C.JSBool {
+	b, ok := propertyBinders.Load(uintptr(cookie))
+	if !ok {
+		return C.JS_FALSE
+	}
+
+	binding := b.(*propertyBinding)
+	if binding.setter == nil {
+		return C.JS_FALSE
+	}
+
+	owner := binding.owner()
+	if owner == nil {
+		return C.JS_FALSE
+	}
+
+	binding.setter(owner, newValue(owner.cx, *val))
+	return C.JS_TRUE
+}
+
+// DefineProperty defines a property keyed by a string or Symbol. value may
+// be nil to define an accessor-only property (no own data slot); getter and
+// setter may each be nil independently, and either or both may be set
+// alongside a value. Array-index keys (including Symbol keys on an array)
+// work the same as on a plain object, since jsid already encodes them.
+func (o *Object) DefineProperty(key PropertyKey, value *Value, getter JsPropertyGetter, setter JsPropertySetter, attrs JsPropertyAttrs) bool {
+	o.cx.rt.lock()
+	defer o.cx.rt.unlock()
+
+	if value == nil && getter == nil && setter == nil {
+		panic("monkey: DefineProperty needs a value or at least one accessor")
+	}
+
+	id := key.toId(o.cx)
+
+	var val C.jsval
+	if value != nil {
+		val = value.val
+	} else {
+		val = C.JSVAL_VOID
+	}
+
+	jsAttrs := C.uintN(uint(attrs))
+	var getterOp, setterOp C.JSPropertyOp
+
+	if getter != nil {
+		cookie := bindAccessor(o, getter, nil)
+		getterOp = C.JSPropertyOp(unsafe.Pointer(newAccessorFunc(o.cx, unsafe.Pointer(C.the_go_property_getter_trampoline), cookie)))
+		jsAttrs |= C.JSPROP_GETTER | C.JSPROP_SHARED
+	}
+
+	if setter != nil {
+		cookie := bindAccessor(o, nil, setter)
+		setterOp = C.JSPropertyOp(unsafe.Pointer(newAccessorFunc(o.cx, unsafe.Pointer(C.the_go_property_setter_trampoline), cookie)))
+		jsAttrs |= C.JSPROP_SETTER | C.JSPROP_SHARED
+	}
+
+	return C.JS_DefinePropertyById(o.cx.jscx, o.obj, id, val, getterOp, setterOp, jsAttrs) == C.JS_TRUE
+}
@@ -0,0 +1,50 @@
+package monkey
+
+import "testing"
+
+func TestDefinePropertyGetterSetter(t *testing.T) {
+	cx := newTestContext()
+	o := cx.NewObject()
+
+	backing := cx.Int(0)
+	get := func(o *Object) *Value { return backing }
+	set := func(o *Object, v *Value) { backing = v }
+
+	if !o.DefineProperty(StringKey("counter"), nil, get, set, JSPROP_ENUMERATE) {
+		t.Fatal("DefineProperty returned false")
+	}
+
+	if v := o.GetProperty("counter"); v == nil {
+		t.Fatal("GetProperty(\"counter\") returned nil after DefineProperty with a getter")
+	}
+}
+
+// TestDefinePropertyRedefineDoesNotLeakBindings guards against the
+// propertyBinders regression where redefining the same accessor property in
+// a loop grew the process-global binding map without bound. Each
+// redefinition here should register a fresh cookie on o, and
+// purgePropertyBindings (what o's finalizer runs) should then remove every
+// one of them from propertyBinders.
+func TestDefinePropertyRedefineDoesNotLeakBindings(t *testing.T) {
+	cx := newTestContext()
+	o := cx.NewObject()
+
+	get := func(o *Object) *Value { return cx.Int(0) }
+
+	const redefines = 50
+	for i := 0; i < redefines; i++ {
+		o.DefineProperty(StringKey("counter"), nil, get, nil, JSPROP_ENUMERATE)
+	}
+
+	if len(o.cookies) != redefines {
+		t.Fatalf("o.cookies has %d entries after %d redefinitions, want %d", len(o.cookies), redefines, redefines)
+	}
+
+	purgePropertyBindings(o)
+
+	for _, cookie := range o.cookies {
+		if _, ok := propertyBinders.Load(cookie); ok {
+			t.Fatalf("propertyBinders still holds cookie %d after purgePropertyBindings", cookie)
+		}
+	}
+}